@@ -6,6 +6,8 @@ type BotAPI interface {
 	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
 	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
 	NewMessage(chatID int64, text string) tgbotapi.MessageConfig
+	NewEditMessage(chatID int64, messageID int, text string) tgbotapi.EditMessageTextConfig
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
 }
 
 type BotAPIWrapper struct {
@@ -27,3 +29,11 @@ func (b *BotAPIWrapper) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.Up
 func (b *BotAPIWrapper) NewMessage(chatID int64, text string) tgbotapi.MessageConfig {
 	return tgbotapi.NewMessage(chatID, text)
 }
+
+func (b *BotAPIWrapper) NewEditMessage(chatID int64, messageID int, text string) tgbotapi.EditMessageTextConfig {
+	return tgbotapi.NewEditMessageText(chatID, messageID, text)
+}
+
+func (b *BotAPIWrapper) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	return b.api.Request(c)
+}
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/artem-streltsov/ucl-timetable-bot/timetable"
+)
+
+const defaultWkPath = "wkhtmltoimage"
+
+var slotHours = []int{9, 10, 11, 12, 13, 14, 15, 16, 17, 18}
+
+var palette = []string{
+	"#4C72B0", "#DD8452", "#55A868", "#C44E52", "#8172B2",
+	"#937860", "#DA8BC3", "#8C8C8C", "#CCB974", "#64B5CD",
+}
+
+func wkPath() string {
+	if path := os.Getenv("WK_PATH"); path != "" {
+		return path
+	}
+	return defaultWkPath
+}
+
+func colorFor(moduleCode string) string {
+	h := fnv.New32a()
+	h.Write([]byte(moduleCode))
+	return palette[int(h.Sum32())%len(palette)]
+}
+
+func buildHTML(lecturesMap map[string][]timetable.Lecture, weekStart time.Time) string {
+	var sb strings.Builder
+	sb.WriteString("<html><head><style>")
+	sb.WriteString("body{font-family:sans-serif;} table{border-collapse:collapse;width:100%;}")
+	sb.WriteString("td,th{border:1px solid #ccc;padding:4px;vertical-align:top;font-size:12px;}")
+	sb.WriteString("</style></head><body><table><tr><th>Time</th>")
+
+	days := []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
+	for i, day := range days {
+		date := weekStart.AddDate(0, 0, i)
+		sb.WriteString(fmt.Sprintf("<th>%s<br>%s</th>", day, date.Format("02 Jan")))
+	}
+	sb.WriteString("</tr>")
+
+	for _, hour := range slotHours {
+		sb.WriteString(fmt.Sprintf("<tr><td>%02d:00</td>", hour))
+		for _, day := range days {
+			sb.WriteString("<td>")
+			for _, lecture := range lecturesMap[day] {
+				if lecture.Start.Hour() != hour {
+					continue
+				}
+				title := timetable.CleanTitle(lecture.Title)
+				color := colorFor(title)
+				// timetable.Lecture carries no lecturer field — the ICS feed
+				// this bot parses doesn't expose one — so the cell shows
+				// room only, not room + lecturer.
+				sb.WriteString(fmt.Sprintf(
+					"<div style=\"background:%s;color:#fff;border-radius:4px;padding:2px 4px;margin-bottom:2px;\">%s<br>%s</div>",
+					color, html.EscapeString(title), html.EscapeString(lecture.Location),
+				))
+			}
+			sb.WriteString("</td>")
+		}
+		sb.WriteString("</tr>")
+	}
+
+	sb.WriteString("</table></body></html>")
+	return sb.String()
+}
+
+// RenderWeekImage converts a week's lectures into a PNG grid using the
+// external wkhtmltoimage binary, whose path is configurable via WK_PATH.
+func RenderWeekImage(lecturesMap map[string][]timetable.Lecture, weekStart time.Time) ([]byte, error) {
+	htmlFile, err := os.CreateTemp("", "timetable-*.html")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp html file: %w", err)
+	}
+	defer os.Remove(htmlFile.Name())
+
+	if _, err := htmlFile.WriteString(buildHTML(lecturesMap, weekStart)); err != nil {
+		htmlFile.Close()
+		return nil, fmt.Errorf("writing temp html file: %w", err)
+	}
+	htmlFile.Close()
+
+	pngFile, err := os.CreateTemp("", "timetable-*.png")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp png file: %w", err)
+	}
+	pngFile.Close()
+	defer os.Remove(pngFile.Name())
+
+	cmd := exec.Command(wkPath(), htmlFile.Name(), pngFile.Name())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("wkhtmltoimage failed: %w: %s", err, output)
+	}
+
+	return os.ReadFile(pngFile.Name())
+}
@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/artem-streltsov/ucl-timetable-bot/database"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	dateLayout         = "2006-01-02"
+	callbackDayPrefix  = "day_"
+	callbackWeekPrefix = "week_"
+	callbackNext       = "next"
+)
+
+func buildDayKeyboard(day time.Time) tgbotapi.InlineKeyboardMarkup {
+	prev := day.AddDate(0, 0, -1).Format(dateLayout)
+	next := day.AddDate(0, 0, 1).Format(dateLayout)
+	today := time.Now().In(ukLocation).Format(dateLayout)
+	navRow := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("◀", callbackDayPrefix+prev),
+		tgbotapi.NewInlineKeyboardButtonData("Today", callbackDayPrefix+today),
+		tgbotapi.NewInlineKeyboardButtonData("▶", callbackDayPrefix+next),
+	)
+	nextLectureRow := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("📝 Next lecture", callbackNext),
+	)
+	return tgbotapi.NewInlineKeyboardMarkup(navRow, nextLectureRow)
+}
+
+func buildWeekKeyboard(weekStart time.Time) tgbotapi.InlineKeyboardMarkup {
+	prev := weekStart.AddDate(0, 0, -7).Format(dateLayout)
+	next := weekStart.AddDate(0, 0, 7).Format(dateLayout)
+	thisWeek := weekStartFor(time.Now().In(ukLocation)).Format(dateLayout)
+	row := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("◀", callbackWeekPrefix+prev),
+		tgbotapi.NewInlineKeyboardButtonData("Today", callbackWeekPrefix+thisWeek),
+		tgbotapi.NewInlineKeyboardButtonData("▶", callbackWeekPrefix+next),
+	)
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+func (s *Scheduler) HandleCallback(update tgbotapi.Update) {
+	query := update.CallbackQuery
+	if query == nil || query.Message == nil {
+		return
+	}
+
+	chatID := query.Message.Chat.ID
+	messageID := query.Message.MessageID
+	data := query.Data
+
+	switch {
+	case data == callbackNext:
+		s.SendNextSummary(chatID)
+	case strings.HasPrefix(data, callbackDayPrefix):
+		s.handleDayCallback(chatID, messageID, strings.TrimPrefix(data, callbackDayPrefix))
+	case strings.HasPrefix(data, callbackWeekPrefix):
+		s.handleWeekCallback(chatID, messageID, strings.TrimPrefix(data, callbackWeekPrefix))
+	}
+
+	s.answerCallback(query.ID)
+}
+
+func (s *Scheduler) handleDayCallback(chatID int64, messageID int, dateStr string) {
+	day, err := time.ParseInLocation(dateLayout, dateStr, ukLocation)
+	if err != nil {
+		return
+	}
+
+	if !s.hasSubscriptions(chatID) {
+		return
+	}
+
+	message, err := s.buildDailyMessage(chatID, day)
+	if err != nil {
+		message = err.Error()
+	}
+	s.editMessage(chatID, messageID, message, buildDayKeyboard(day))
+}
+
+func (s *Scheduler) handleWeekCallback(chatID int64, messageID int, dateStr string) {
+	weekStart, err := time.ParseInLocation(dateLayout, dateStr, ukLocation)
+	if err != nil {
+		return
+	}
+
+	if !s.hasSubscriptions(chatID) {
+		return
+	}
+
+	user, _ := s.db.GetUser(chatID)
+	if user != nil && user.Format == database.FormatImage {
+		// A photo message has no text to retarget via editMessageText, so
+		// send a fresh rendered image for the requested week instead.
+		s.sendWeeklyImage(chatID, weekStart)
+		return
+	}
+
+	message, err := s.buildWeeklyMessage(chatID, weekStart)
+	if err != nil {
+		message = err.Error()
+	}
+	s.editMessage(chatID, messageID, message, buildWeekKeyboard(weekStart))
+}
+
+func (s *Scheduler) editMessage(chatID int64, messageID int, text string, keyboard tgbotapi.InlineKeyboardMarkup) {
+	edit := s.api.NewEditMessage(chatID, messageID, text)
+	edit.ParseMode = "Markdown"
+	edit.ReplyMarkup = &keyboard
+	if _, err := s.api.Send(edit); err != nil {
+		log.Printf("Error editing message: %v", err)
+	}
+}
+
+func (s *Scheduler) answerCallback(callbackID string) {
+	callback := tgbotapi.NewCallback(callbackID, "")
+	if _, err := s.api.Request(callback); err != nil {
+		log.Printf("Error answering callback: %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/artem-streltsov/ucl-timetable-bot/database"
+	"github.com/artem-streltsov/ucl-timetable-bot/timetable"
+)
+
+const (
+	changeDetectionInterval = 5 * time.Minute
+	activeWindowStartHour   = 7
+	activeWindowEndHour     = 23
+)
+
+func inActiveWindow(t time.Time) bool {
+	hour := t.In(ukLocation).Hour()
+	return hour >= activeWindowStartHour && hour < activeWindowEndHour
+}
+
+func hashSummary(event timetable.Lecture) string {
+	sum := sha1.Sum([]byte(event.Title + "|" + event.Location))
+	return hex.EncodeToString(sum[:])
+}
+
+type changeKind int
+
+const (
+	changeNone changeKind = iota
+	changeAdded
+	changeMoved
+	changeRoom
+	changeDetails
+)
+
+// eventChange is a classified diff for one event, carrying enough of the old
+// and new snapshot to format a message without redoing the comparison.
+type eventChange struct {
+	event SourcedLecture
+	snap  database.EventSnapshot
+	prev  database.EventSnapshot
+	kind  changeKind
+}
+
+// diffEvents classifies each current event against the previous snapshot set
+// and returns the snapshots to persist plus the changes to report. It does
+// not touch the database or format any text, so it can be tested in
+// isolation from I/O. On a user's first-ever poll (no previous snapshots),
+// every event is treated as a new baseline rather than reported as added.
+func diffEvents(events []SourcedLecture, previous []database.EventSnapshot) (current []database.EventSnapshot, changes []eventChange, removed int) {
+	firstPoll := len(previous) == 0
+	prevByUID := make(map[string]database.EventSnapshot, len(previous))
+	for _, snap := range previous {
+		prevByUID[snap.UID] = snap
+	}
+
+	current = make([]database.EventSnapshot, 0, len(events))
+	seen := make(map[string]bool, len(events))
+
+	for _, event := range events {
+		snap := database.EventSnapshot{
+			UID:         event.UID,
+			Start:       event.Start,
+			End:         event.End,
+			Location:    event.Location,
+			SummaryHash: hashSummary(event.Lecture),
+		}
+		current = append(current, snap)
+		seen[snap.UID] = true
+
+		if firstPoll {
+			continue
+		}
+
+		prev, existed := prevByUID[snap.UID]
+		kind := changeNone
+		switch {
+		case !existed:
+			kind = changeAdded
+		case !prev.Start.Equal(snap.Start):
+			kind = changeMoved
+		case prev.Location != snap.Location:
+			kind = changeRoom
+		case prev.SummaryHash != snap.SummaryHash:
+			kind = changeDetails
+		}
+		if kind != changeNone {
+			changes = append(changes, eventChange{event: event, snap: snap, prev: prev, kind: kind})
+		}
+	}
+
+	for uid := range prevByUID {
+		if !seen[uid] {
+			removed++
+		}
+	}
+
+	return current, changes, removed
+}
+
+func formatChange(c eventChange) string {
+	title := labelTitle(c.event.Sub, timetable.CleanTitle(c.event.Title))
+	switch c.kind {
+	case changeAdded:
+		return fmt.Sprintf("➕ *%s* added at %s, room %s", title, c.snap.Start.Format("15:04"), c.snap.Location)
+	case changeMoved:
+		return fmt.Sprintf("🔄 *%s* moved from %s → %s, room %s", title, c.prev.Start.Format("15:04"), c.snap.Start.Format("15:04"), c.snap.Location)
+	case changeRoom:
+		return fmt.Sprintf("📍 *%s* moved to room %s", title, c.snap.Location)
+	default:
+		return fmt.Sprintf("✏️ *%s* details changed", title)
+	}
+}
+
+func (s *Scheduler) checkForChanges(chatID int64) {
+	subs, err := s.db.GetSubscriptions(chatID)
+	if err != nil || len(subs) == 0 {
+		return
+	}
+
+	mergedUIDs := make(map[string]bool)
+	var events []SourcedLecture
+	for _, sub := range subs {
+		cal, err := timetable.FetchCalendar(sub.WebCalURL)
+		if err != nil {
+			continue
+		}
+		subEvents, err := timetable.GetAllEvents(cal)
+		if err != nil {
+			continue
+		}
+		for _, event := range subEvents {
+			if sourced, ok := addUnseen(mergedUIDs, event.UID, event, sub); ok {
+				events = append(events, sourced)
+			}
+		}
+	}
+
+	previous, err := s.db.GetEventSnapshots(chatID)
+	if err != nil {
+		return
+	}
+
+	current, changes, removed := diffEvents(events, previous)
+
+	if err := s.db.SaveEventSnapshots(chatID, current); err != nil {
+		return
+	}
+
+	var diffs []string
+	for _, c := range changes {
+		diffs = append(diffs, formatChange(c))
+	}
+	for i := 0; i < removed; i++ {
+		diffs = append(diffs, "➖ A lecture was removed from your timetable")
+	}
+
+	if len(diffs) == 0 {
+		return
+	}
+
+	s.sendMessage(chatID, "🔔 *Timetable updated:*\n\n"+strings.Join(diffs, "\n"))
+	s.scheduleLectureReminders(chatID)
+}
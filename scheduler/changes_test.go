@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/artem-streltsov/ucl-timetable-bot/database"
+	"github.com/artem-streltsov/ucl-timetable-bot/timetable"
+)
+
+func TestHashSummary(t *testing.T) {
+	a := timetable.Lecture{Title: "Algorithms", Location: "MPEB 1.03"}
+	b := timetable.Lecture{Title: "Algorithms", Location: "MPEB 1.03"}
+	c := timetable.Lecture{Title: "Algorithms", Location: "MPEB 1.04"}
+	d := timetable.Lecture{Title: "Compilers", Location: "MPEB 1.03"}
+
+	if hashSummary(a) != hashSummary(b) {
+		t.Error("identical title+location should hash the same")
+	}
+	if hashSummary(a) == hashSummary(c) {
+		t.Error("different location should hash differently")
+	}
+	if hashSummary(a) == hashSummary(d) {
+		t.Error("different title should hash differently")
+	}
+}
+
+func TestDiffEventsFirstPoll(t *testing.T) {
+	sub := database.Subscription{Label: "Programme"}
+	events := []SourcedLecture{
+		{Lecture: timetable.Lecture{UID: "1", Title: "Algorithms", Location: "MPEB 1.03", Start: mustTime("09:00")}, Sub: sub},
+		{Lecture: timetable.Lecture{UID: "2", Title: "Compilers", Location: "MPEB 1.04", Start: mustTime("10:00")}, Sub: sub},
+	}
+
+	current, changes, removed := diffEvents(events, nil)
+
+	if len(current) != 2 {
+		t.Fatalf("expected 2 snapshots saved, got %d", len(current))
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no reported changes on first poll, got %d", len(changes))
+	}
+	if removed != 0 {
+		t.Errorf("expected no removals on first poll, got %d", removed)
+	}
+}
+
+func TestDiffEventsAdded(t *testing.T) {
+	sub := database.Subscription{Label: "Programme"}
+	previous := []database.EventSnapshot{
+		{UID: "1", Start: mustTime("09:00"), Location: "MPEB 1.03", SummaryHash: hashSummary(timetable.Lecture{Title: "Algorithms", Location: "MPEB 1.03"})},
+	}
+	events := []SourcedLecture{
+		{Lecture: timetable.Lecture{UID: "1", Title: "Algorithms", Location: "MPEB 1.03", Start: mustTime("09:00")}, Sub: sub},
+		{Lecture: timetable.Lecture{UID: "2", Title: "Compilers", Location: "MPEB 1.04", Start: mustTime("10:00")}, Sub: sub},
+	}
+
+	_, changes, removed := diffEvents(events, previous)
+
+	if removed != 0 {
+		t.Errorf("expected no removals, got %d", removed)
+	}
+	if len(changes) != 1 || changes[0].kind != changeAdded || changes[0].event.UID != "2" {
+		t.Fatalf("expected a single changeAdded for UID 2, got %+v", changes)
+	}
+}
+
+func TestDiffEventsMovedTime(t *testing.T) {
+	sub := database.Subscription{Label: "Programme"}
+	previous := []database.EventSnapshot{
+		{UID: "1", Start: mustTime("09:00"), Location: "MPEB 1.03", SummaryHash: hashSummary(timetable.Lecture{Title: "Algorithms", Location: "MPEB 1.03"})},
+	}
+	events := []SourcedLecture{
+		{Lecture: timetable.Lecture{UID: "1", Title: "Algorithms", Location: "MPEB 1.03", Start: mustTime("10:00")}, Sub: sub},
+	}
+
+	_, changes, _ := diffEvents(events, previous)
+
+	if len(changes) != 1 || changes[0].kind != changeMoved {
+		t.Fatalf("expected a single changeMoved, got %+v", changes)
+	}
+}
+
+func TestDiffEventsMovedRoom(t *testing.T) {
+	sub := database.Subscription{Label: "Programme"}
+	previous := []database.EventSnapshot{
+		{UID: "1", Start: mustTime("09:00"), Location: "MPEB 1.03", SummaryHash: hashSummary(timetable.Lecture{Title: "Algorithms", Location: "MPEB 1.03"})},
+	}
+	events := []SourcedLecture{
+		{Lecture: timetable.Lecture{UID: "1", Title: "Algorithms", Location: "MPEB 1.04", Start: mustTime("09:00")}, Sub: sub},
+	}
+
+	_, changes, _ := diffEvents(events, previous)
+
+	if len(changes) != 1 || changes[0].kind != changeRoom {
+		t.Fatalf("expected a single changeRoom, got %+v", changes)
+	}
+}
+
+func TestDiffEventsDetailsChanged(t *testing.T) {
+	sub := database.Subscription{Label: "Programme"}
+	previous := []database.EventSnapshot{
+		{UID: "1", Start: mustTime("09:00"), Location: "MPEB 1.03", SummaryHash: hashSummary(timetable.Lecture{Title: "Algorithms", Location: "MPEB 1.03"})},
+	}
+	events := []SourcedLecture{
+		{Lecture: timetable.Lecture{UID: "1", Title: "Algorithms (resit)", Location: "MPEB 1.03", Start: mustTime("09:00")}, Sub: sub},
+	}
+
+	_, changes, _ := diffEvents(events, previous)
+
+	if len(changes) != 1 || changes[0].kind != changeDetails {
+		t.Fatalf("expected a single changeDetails, got %+v", changes)
+	}
+}
+
+func TestDiffEventsRemoved(t *testing.T) {
+	previous := []database.EventSnapshot{
+		{UID: "1", Start: mustTime("09:00"), Location: "MPEB 1.03"},
+	}
+
+	current, changes, removed := diffEvents(nil, previous)
+
+	if len(current) != 0 {
+		t.Errorf("expected no current snapshots, got %d", len(current))
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no per-event changes, got %d", len(changes))
+	}
+	if removed != 1 {
+		t.Errorf("expected 1 removal, got %d", removed)
+	}
+}
+
+func mustTime(hhmm string) time.Time {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
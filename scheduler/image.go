@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/artem-streltsov/ucl-timetable-bot/database"
+	"github.com/artem-streltsov/ucl-timetable-bot/render"
+	"github.com/artem-streltsov/ucl-timetable-bot/timetable"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func calendarHash(lecturesMap map[string][]timetable.Lecture) string {
+	sum := sha1.New()
+	for _, day := range []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"} {
+		for _, lecture := range lecturesMap[day] {
+			fmt.Fprintf(sum, "%s|%s|%s|", lecture.Title, lecture.Location, lecture.Start)
+		}
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+func (s *Scheduler) sendWeeklyImage(chatID int64, weekStart time.Time) {
+	weekEnd := weekStart.AddDate(0, 0, 4)
+	lecturesMap, err := s.fetchLecturesInRange(chatID, weekStart, weekEnd)
+	if err != nil {
+		s.sendMessage(chatID, "Error processing calendar: "+err.Error())
+		return
+	}
+	if len(lecturesMap) == 0 {
+		s.sendMessage(chatID, "No lectures this week.")
+		return
+	}
+
+	plainMap := plainLecturesMap(lecturesMap)
+	hash := calendarHash(plainMap)
+
+	png, err := s.db.GetCachedFile(chatID, weekStart, hash)
+	if err != nil || png == nil {
+		png, err = render.RenderWeekImage(plainMap, weekStart)
+		if err != nil {
+			s.sendMessage(chatID, "Error rendering timetable image: "+err.Error())
+			return
+		}
+		if err := s.db.SaveCachedFile(chatID, weekStart, hash, png); err != nil {
+			log.Printf("Error caching timetable image: %v", err)
+		}
+	}
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("timetable-%s.png", weekStart.Format(dateLayout)),
+		Bytes: png,
+	})
+	photo.ReplyMarkup = buildWeekKeyboard(weekStart)
+	if _, err := s.api.Send(photo); err != nil {
+		log.Printf("Error sending timetable image: %v", err)
+	}
+}
+
+func (s *Scheduler) HandleSetFormat(chatID int64, format string) error {
+	switch format {
+	case database.FormatText, database.FormatImage:
+	default:
+		return fmt.Errorf("unknown format %q, expected %q or %q", format, database.FormatText, database.FormatImage)
+	}
+	return s.db.SetFormat(chatID, format)
+}
@@ -0,0 +1,173 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/artem-streltsov/ucl-timetable-bot/database"
+	"github.com/artem-streltsov/ucl-timetable-bot/timetable"
+	"github.com/artem-streltsov/ucl-timetable-bot/utils"
+)
+
+const jobPollInterval = 30 * time.Second
+
+type lectureReminderPayload struct {
+	Title    string `json:"title"`
+	Location string `json:"location"`
+	Offset   int    `json:"offset"`
+}
+
+// Start begins the ticker goroutine that claims and executes due jobs.
+// Pending jobs are persisted in the database, so reminders survive restarts.
+func (s *Scheduler) Start() {
+	s.stopCh = make(chan struct{})
+	ticker := time.NewTicker(jobPollInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.runDueJobs()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *Scheduler) Stop() {
+	if s.stopCh != nil {
+		close(s.stopCh)
+	}
+}
+
+func (s *Scheduler) runDueJobs() {
+	jobs, err := s.db.ClaimDueJobs(time.Now())
+	if err != nil {
+		log.Printf("Error claiming due jobs: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		s.executeJob(job)
+	}
+}
+
+func (s *Scheduler) executeJob(job database.Job) {
+	switch job.Kind {
+	case database.JobKindDaily:
+		s.sendDailyTimetable(job.ChatID)
+		s.scheduleDailyJob(job.ChatID)
+	case database.JobKindWeekly:
+		s.sendWeeklyTimetable(job.ChatID)
+		s.scheduleWeeklyJob(job.ChatID)
+	case database.JobKindLectureScheduler:
+		s.scheduleLectureReminders(job.ChatID)
+		s.scheduleLectureSchedulerJob(job.ChatID)
+	case database.JobKindLectureReminder:
+		s.executeLectureReminder(job)
+	case database.JobKindChangeDetection:
+		if inActiveWindow(time.Now()) {
+			s.checkForChanges(job.ChatID)
+		}
+		s.scheduleChangeDetectionJob(job.ChatID)
+	}
+}
+
+func (s *Scheduler) executeLectureReminder(job database.Job) {
+	var payload lectureReminderPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		log.Printf("Error decoding lecture reminder payload: %v", err)
+		return
+	}
+	message := fmt.Sprintf("⏰ *%s* in %d minutes at %s", payload.Title, payload.Offset, payload.Location)
+	s.sendMessage(job.ChatID, message)
+}
+
+func (s *Scheduler) scheduleDailyJob(chatID int64) {
+	user, _ := s.db.GetUser(chatID)
+	if user == nil {
+		return
+	}
+	runAt := utils.GetNextTime(user.DailyTime)
+	if err := s.db.UpsertJob(chatID, database.JobKindDaily, runAt, ""); err != nil {
+		log.Printf("Error scheduling daily job: %v", err)
+	}
+}
+
+func (s *Scheduler) scheduleWeeklyJob(chatID int64) {
+	user, _ := s.db.GetUser(chatID)
+	if user == nil {
+		return
+	}
+	runAt := utils.GetNextWeekTime(user.WeeklyTime)
+	if err := s.db.UpsertJob(chatID, database.JobKindWeekly, runAt, ""); err != nil {
+		log.Printf("Error scheduling weekly job: %v", err)
+	}
+}
+
+func (s *Scheduler) scheduleLectureSchedulerJob(chatID int64) {
+	now := time.Now().In(ukLocation)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 1, 0, ukLocation).AddDate(0, 0, 1)
+	if err := s.db.UpsertJob(chatID, database.JobKindLectureScheduler, midnight, ""); err != nil {
+		log.Printf("Error scheduling lecture scheduler job: %v", err)
+	}
+}
+
+func (s *Scheduler) scheduleChangeDetectionJob(chatID int64) {
+	runAt := time.Now().Add(changeDetectionInterval)
+	if err := s.db.UpsertJob(chatID, database.JobKindChangeDetection, runAt, ""); err != nil {
+		log.Printf("Error scheduling change detection job: %v", err)
+	}
+}
+
+func (s *Scheduler) scheduleLectureReminders(chatID int64) {
+	user, _ := s.db.GetUser(chatID)
+	if user == nil || !s.hasSubscriptions(chatID) {
+		if err := s.db.ReplaceLectureReminderJobs(chatID, nil); err != nil {
+			log.Printf("Error clearing lecture reminder jobs: %v", err)
+		}
+		return
+	}
+
+	day := time.Now().In(ukLocation)
+	lectures, err := s.fetchLecturesForDay(chatID, day)
+	if err != nil {
+		return
+	}
+
+	offsetMinutes, err := strconv.Atoi(user.ReminderOffset)
+	if err != nil {
+		offsetMinutes = 15
+	}
+
+	now := time.Now().In(ukLocation)
+	var jobs []database.Job
+
+	for _, lecture := range lectures {
+		reminderTime := lecture.Start.Add(-time.Duration(offsetMinutes) * time.Minute)
+		if !reminderTime.After(now) {
+			continue
+		}
+		payload, err := json.Marshal(lectureReminderPayload{
+			Title:    labelTitle(lecture.Sub, timetable.CleanTitle(lecture.Title)),
+			Location: lecture.Location,
+			Offset:   offsetMinutes,
+		})
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, database.Job{
+			ChatID:  chatID,
+			Kind:    database.JobKindLectureReminder,
+			RunAt:   reminderTime,
+			Payload: string(payload),
+		})
+	}
+
+	if err := s.db.ReplaceLectureReminderJobs(chatID, jobs); err != nil {
+		log.Printf("Error saving lecture reminder jobs: %v", err)
+	}
+}
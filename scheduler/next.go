@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/artem-streltsov/ucl-timetable-bot/database"
+	"github.com/artem-streltsov/ucl-timetable-bot/timetable"
+)
+
+type lecturePair struct {
+	Title    string
+	Location string
+	Start    time.Time
+	End      time.Time
+	Sub      database.Subscription
+}
+
+func (s *Scheduler) SendNextSummary(chatID int64) {
+	if !s.hasSubscriptions(chatID) {
+		s.sendMessage(chatID, "Please add a calendar using /add_calendar <url> <label>")
+		return
+	}
+
+	subs, err := s.db.GetSubscriptions(chatID)
+	if err != nil {
+		s.sendMessage(chatID, "Error loading calendars: "+err.Error())
+		return
+	}
+
+	now := time.Now().In(ukLocation)
+	var next *SourcedLecture
+	for _, sub := range subs {
+		cal, err := timetable.FetchCalendar(sub.WebCalURL)
+		if err != nil {
+			continue
+		}
+		candidate, err := timetable.GetNextLecture(cal, now)
+		if err != nil || candidate == nil {
+			continue
+		}
+		if next == nil || candidate.Start.Before(next.Start) {
+			next = &SourcedLecture{Lecture: *candidate, Sub: sub}
+		}
+	}
+	if next == nil {
+		s.sendMessage(chatID, "❗️No upcoming lectures found.")
+		return
+	}
+
+	day := next.Start.In(ukLocation)
+	dayLectures, err := s.fetchLecturesForDay(chatID, day)
+	if err != nil || len(dayLectures) == 0 {
+		dayLectures = []SourcedLecture{*next}
+	}
+	pair := groupPair(dayLectures, *next)
+
+	title := labelTitle(pair.Sub, timetable.CleanTitle(pair.Title))
+	s.sendMessage(chatID, formatNextSummary(pair, title, now, day))
+}
+
+func formatNextSummary(pair lecturePair, title string, now, day time.Time) string {
+	if sameDay(day, now) {
+		return fmt.Sprintf("📝 Next: *%s* in %s at %s", title, formatDuration(time.Until(pair.Start)), pair.Location)
+	}
+	return fmt.Sprintf("❗️No lectures today. Next: *%s* %s at %s", title, relativeDay(day, now), pair.Start.Format("15:04"))
+}
+
+func groupPair(dayLectures []SourcedLecture, start SourcedLecture) lecturePair {
+	pair := lecturePair{Title: start.Title, Location: start.Location, Start: start.Start, End: start.End, Sub: start.Sub}
+	for {
+		extended := false
+		for _, lecture := range dayLectures {
+			if lecture.Title == pair.Title && lecture.Sub.Label == pair.Sub.Label && lecture.Start.Equal(pair.End) {
+				pair.End = lecture.End
+				extended = true
+			}
+		}
+		if !extended {
+			break
+		}
+	}
+	return pair
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+func relativeDay(day, now time.Time) string {
+	if sameDay(day, now.AddDate(0, 0, 1)) {
+		return "tomorrow"
+	}
+	return "on " + day.Format("Monday")
+}
+
+func pluralize(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return "under a minute"
+	}
+	minutes := int(d.Minutes())
+	if minutes < 60 {
+		return pluralize(minutes, "minute")
+	}
+	hours := minutes / 60
+	mins := minutes % 60
+	if mins == 0 {
+		return pluralize(hours, "hour")
+	}
+	return fmt.Sprintf("%dh %dm", hours, mins)
+}
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/artem-streltsov/ucl-timetable-bot/database"
+)
+
+func TestFormatDuration(t *testing.T) {
+	cases := []struct {
+		d    time.Duration
+		want string
+	}{
+		{30 * time.Second, "under a minute"},
+		{1 * time.Minute, "1 minute"},
+		{43 * time.Minute, "43 minutes"},
+		{1 * time.Hour, "1 hour"},
+		{2 * time.Hour, "2 hours"},
+		{90 * time.Minute, "1h 30m"},
+	}
+	for _, c := range cases {
+		if got := formatDuration(c.d); got != c.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", c.d, got, c.want)
+		}
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	if got := pluralize(1, "minute"); got != "1 minute" {
+		t.Errorf("pluralize(1, minute) = %q, want %q", got, "1 minute")
+	}
+	if got := pluralize(2, "minute"); got != "2 minutes" {
+		t.Errorf("pluralize(2, minute) = %q, want %q", got, "2 minutes")
+	}
+	if got := pluralize(0, "minute"); got != "0 minutes" {
+		t.Errorf("pluralize(0, minute) = %q, want %q", got, "0 minutes")
+	}
+}
+
+func TestGroupPairExtendsAdjacentSameTitle(t *testing.T) {
+	sub := database.Subscription{Label: "Programme"}
+	start := SourcedLecture{}
+	start.Title = "Algorithms"
+	start.Start = mustTime("09:00")
+	start.End = mustTime("10:00")
+	start.Sub = sub
+
+	second := SourcedLecture{}
+	second.Title = "Algorithms"
+	second.Start = mustTime("10:00")
+	second.End = mustTime("11:00")
+	second.Sub = sub
+
+	unrelated := SourcedLecture{}
+	unrelated.Title = "Compilers"
+	unrelated.Start = mustTime("11:00")
+	unrelated.End = mustTime("12:00")
+	unrelated.Sub = sub
+
+	pair := groupPair([]SourcedLecture{start, second, unrelated}, start)
+
+	if !pair.End.Equal(second.End) {
+		t.Errorf("expected pair to extend through the adjacent same-title lecture, got End=%v", pair.End)
+	}
+}
+
+func TestGroupPairStopsAtDifferentSubscription(t *testing.T) {
+	subA := database.Subscription{Label: "Programme"}
+	subB := database.Subscription{Label: "Friend"}
+
+	start := SourcedLecture{}
+	start.Title = "Algorithms"
+	start.Start = mustTime("09:00")
+	start.End = mustTime("10:00")
+	start.Sub = subA
+
+	overlap := SourcedLecture{}
+	overlap.Title = "Algorithms"
+	overlap.Start = mustTime("10:00")
+	overlap.End = mustTime("11:00")
+	overlap.Sub = subB
+
+	pair := groupPair([]SourcedLecture{start, overlap}, start)
+
+	if !pair.End.Equal(start.End) {
+		t.Errorf("expected pair not to merge across subscriptions, got End=%v", pair.End)
+	}
+}
@@ -3,13 +3,12 @@ package scheduler
 import (
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/artem-streltsov/ucl-timetable-bot/common"
 	"github.com/artem-streltsov/ucl-timetable-bot/database"
 	"github.com/artem-streltsov/ucl-timetable-bot/timetable"
-	"github.com/artem-streltsov/ucl-timetable-bot/utils"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -17,23 +16,15 @@ import (
 var ukLocation, _ = time.LoadLocation("Europe/London")
 
 type Scheduler struct {
-	api    *tgbotapi.BotAPI
+	api    common.BotAPI
 	db     *database.DB
-	timers map[int64]*UserTimers
+	stopCh chan struct{}
 }
 
-type UserTimers struct {
-	dailyTimer       *time.Timer
-	weeklyTimer      *time.Timer
-	lectureTimers    []*time.Timer
-	lectureScheduler *time.Timer
-}
-
-func NewScheduler(api *tgbotapi.BotAPI, db *database.DB) *Scheduler {
+func NewScheduler(api common.BotAPI, db *database.DB) *Scheduler {
 	return &Scheduler{
-		api:    api,
-		db:     db,
-		timers: make(map[int64]*UserTimers),
+		api: api,
+		db:  db,
 	}
 }
 
@@ -52,147 +43,99 @@ func (s *Scheduler) ScheduleUser(chatID int64) {
 
 	s.CancelUser(chatID)
 
-	s.timers[chatID] = &UserTimers{}
-
-	dailyTime := utils.GetNextTime(user.DailyTime)
-	dailyDuration := time.Until(dailyTime)
-	dailyTimer := time.AfterFunc(dailyDuration, func() {
-		s.sendDailyTimetable(chatID)
-		s.ScheduleUser(chatID)
-	})
-	s.timers[chatID].dailyTimer = dailyTimer
-
-	weeklyTime := utils.GetNextWeekTime(user.WeeklyTime)
-	weeklyDuration := time.Until(weeklyTime)
-	weeklyTimer := time.AfterFunc(weeklyDuration, func() {
-		s.sendWeeklyTimetable(chatID)
-		s.ScheduleUser(chatID)
-	})
-	s.timers[chatID].weeklyTimer = weeklyTimer
-
-	s.scheduleLectureRemindersAtMidnight(chatID)
-}
-
-func (s *Scheduler) scheduleLectureRemindersAtMidnight(chatID int64) {
-	now := time.Now().In(ukLocation)
-	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 1, 0, ukLocation).AddDate(0, 0, 1)
-	durationUntilMidnight := time.Until(midnight)
-
-	lectureScheduler := time.AfterFunc(durationUntilMidnight, func() {
-		s.scheduleLectureReminders(chatID)
-		s.scheduleLectureRemindersAtMidnight(chatID)
-	})
-	s.timers[chatID].lectureScheduler = lectureScheduler
-
+	s.scheduleDailyJob(chatID)
+	s.scheduleWeeklyJob(chatID)
+	s.scheduleLectureSchedulerJob(chatID)
+	s.scheduleChangeDetectionJob(chatID)
 	s.scheduleLectureReminders(chatID)
 }
 
-func (s *Scheduler) scheduleLectureReminders(chatID int64) {
-	if s.timers[chatID] != nil {
-		for _, timer := range s.timers[chatID].lectureTimers {
-			timer.Stop()
-		}
-		s.timers[chatID].lectureTimers = nil
+func (s *Scheduler) CancelUser(chatID int64) {
+	if err := s.db.DeleteJobsForUser(chatID); err != nil {
+		log.Printf("Error cancelling jobs for user %d: %v", chatID, err)
 	}
+}
 
-	user, _ := s.db.GetUser(chatID)
-	if user == nil || user.WebCalURL == "" {
-		return
-	}
+func (s *Scheduler) sendDailyTimetable(chatID int64) {
+	s.sendDailyTimetableForDate(chatID, time.Now().In(ukLocation))
+}
 
-	cal, err := timetable.FetchCalendar(user.WebCalURL)
-	if err != nil {
+func (s *Scheduler) sendDailyTimetableForDate(chatID int64, day time.Time) {
+	if !s.hasSubscriptions(chatID) {
+		s.sendMessage(chatID, "Please add a calendar using /add_calendar <url> <label>")
 		return
 	}
-
-	day := time.Now().In(ukLocation)
-	lectures, err := timetable.GetLectures(cal, day)
-	if err != nil || len(lectures) == 0 {
+	message, err := s.buildDailyMessage(chatID, day)
+	if err != nil {
+		s.sendMessage(chatID, err.Error())
 		return
 	}
-
-	offsetMinutes, err := strconv.Atoi(user.ReminderOffset)
-	if err != nil {
-		offsetMinutes = 15
-	}
-
-	timers := []*time.Timer{}
-	now := time.Now().In(ukLocation)
-
-	for _, lecture := range lectures {
-		reminderTime := lecture.Start.Add(-time.Duration(offsetMinutes) * time.Minute)
-		if reminderTime.After(now) {
-			duration := time.Until(reminderTime)
-			lectureCopy := lecture
-			timer := time.AfterFunc(duration, func() {
-				reminderMessage := fmt.Sprintf("⏰ *%s* in %d minutes at %s",
-					timetable.CleanTitle(lectureCopy.Title),
-					offsetMinutes,
-					lectureCopy.Location,
-				)
-				s.sendMessage(chatID, reminderMessage)
-			})
-			timers = append(timers, timer)
-		}
+	msg := tgbotapi.NewMessage(chatID, message)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = buildDayKeyboard(day)
+	if _, err := s.api.Send(msg); err != nil {
+		log.Printf("Error sending message: %v", err)
 	}
-	s.timers[chatID].lectureTimers = timers
 }
 
-func (s *Scheduler) sendDailyTimetable(chatID int64) {
-	user, _ := s.db.GetUser(chatID)
-	if user == nil || user.WebCalURL == "" {
-		s.sendMessage(chatID, "Please set your calendar link using /set_calendar")
-		return
-	}
-	cal, err := timetable.FetchCalendar(user.WebCalURL)
+func (s *Scheduler) buildDailyMessage(chatID int64, day time.Time) (string, error) {
+	lectures, err := s.fetchLecturesForDay(chatID, day)
 	if err != nil {
-		s.sendMessage(chatID, "Error fetching calendar: "+err.Error())
-		return
-	}
-
-	day := time.Now().In(ukLocation)
-	lectures, err := timetable.GetLectures(cal, day)
-	if err != nil {
-		s.sendMessage(chatID, "Error processing calendar: "+err.Error())
-		return
+		return "", fmt.Errorf("Error processing calendar: %w", err)
 	}
 	if len(lectures) == 0 {
-		s.sendMessage(chatID, "No lectures today.")
-		return
+		return fmt.Sprintf("*%s:*\n\nNo lectures today.", day.Format("Mon, 02 Jan")), nil
 	}
 	dateStr := day.Format("Mon, 02 Jan")
-	message := fmt.Sprintf("*%s:*\n\n", dateStr) + timetable.FormatLectures(lectures)
-	s.sendMessage(chatID, message)
+	return fmt.Sprintf("*%s:*\n\n", dateStr) + timetable.FormatLectures(plainLectures(lectures)), nil
 }
 
 func (s *Scheduler) sendWeeklyTimetable(chatID int64) {
+	s.sendWeeklyTimetableForWeek(chatID, weekStartFor(time.Now().In(ukLocation)))
+}
+
+func (s *Scheduler) sendWeeklyTimetableForWeek(chatID int64, weekStart time.Time) {
 	user, _ := s.db.GetUser(chatID)
-	if user == nil || user.WebCalURL == "" {
-		s.sendMessage(chatID, "Please set your calendar link using /set_calendar")
+	if user == nil || !s.hasSubscriptions(chatID) {
+		s.sendMessage(chatID, "Please add a calendar using /add_calendar <url> <label>")
 		return
 	}
-	cal, err := timetable.FetchCalendar(user.WebCalURL)
+
+	if user.Format == database.FormatImage {
+		s.sendWeeklyImage(chatID, weekStart)
+		return
+	}
+
+	message, err := s.buildWeeklyMessage(chatID, weekStart)
 	if err != nil {
-		s.sendMessage(chatID, "Error fetching calendar: "+err.Error())
+		s.sendMessage(chatID, err.Error())
 		return
 	}
+	msg := tgbotapi.NewMessage(chatID, message)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = buildWeekKeyboard(weekStart)
+	if _, err := s.api.Send(msg); err != nil {
+		log.Printf("Error sending message: %v", err)
+	}
+}
 
-	now := time.Now().In(ukLocation)
+func weekStartFor(now time.Time) time.Time {
 	weekday := int(now.Weekday())
 	if weekday == 0 {
 		weekday = 7 // make Sunday 7
 	}
-	weekStart := now.AddDate(0, 0, -(weekday - 1)) // Monday
-	weekEnd := weekStart.AddDate(0, 0, 4)          // Friday
+	return now.AddDate(0, 0, -(weekday - 1)) // Monday
+}
+
+func (s *Scheduler) buildWeeklyMessage(chatID int64, weekStart time.Time) (string, error) {
+	weekEnd := weekStart.AddDate(0, 0, 4) // Friday
 
-	lecturesMap, err := timetable.GetLecturesInRange(cal, weekStart, weekEnd)
+	lecturesMap, err := s.fetchLecturesInRange(chatID, weekStart, weekEnd)
 	if err != nil {
-		s.sendMessage(chatID, "Error processing calendar: "+err.Error())
-		return
+		return "", fmt.Errorf("Error processing calendar: %w", err)
 	}
 	if len(lecturesMap) == 0 {
-		s.sendMessage(chatID, "No lectures this week.")
-		return
+		return "No lectures this week.", nil
 	}
 	startDateStr := weekStart.Format("Mon, 02 Jan")
 	endDateStr := weekEnd.Format("Fri, 02 Jan")
@@ -205,11 +148,16 @@ func (s *Scheduler) sendWeeklyTimetable(chatID int64) {
 		lectures, ok := lecturesMap[dayKey]
 		if ok {
 			sb.WriteString("\n" + "*" + dayKey + "*" + "\n")
-			message := timetable.FormatLectures(lectures)
+			message := timetable.FormatLectures(plainLectures(lectures))
 			sb.WriteString(message)
 		}
 	}
-	s.sendMessage(chatID, sb.String())
+	return sb.String(), nil
+}
+
+func (s *Scheduler) hasSubscriptions(chatID int64) bool {
+	subs, err := s.db.GetSubscriptions(chatID)
+	return err == nil && len(subs) > 0
 }
 
 func (s *Scheduler) sendMessage(chatID int64, text string) {
@@ -219,27 +167,3 @@ func (s *Scheduler) sendMessage(chatID int64, text string) {
 		log.Printf("Error sending message: %v", err)
 	}
 }
-
-func (s *Scheduler) CancelUser(chatID int64) {
-	if timers, exists := s.timers[chatID]; exists {
-		if timers.dailyTimer != nil {
-			timers.dailyTimer.Stop()
-		}
-		if timers.weeklyTimer != nil {
-			timers.weeklyTimer.Stop()
-		}
-		if timers.lectureScheduler != nil {
-			timers.lectureScheduler.Stop()
-		}
-		for _, timer := range timers.lectureTimers {
-			timer.Stop()
-		}
-		delete(s.timers, chatID)
-	}
-}
-
-func (s *Scheduler) StopAll() {
-	for chatID := range s.timers {
-		s.CancelUser(chatID)
-	}
-}
@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/artem-streltsov/ucl-timetable-bot/database"
+	"github.com/artem-streltsov/ucl-timetable-bot/timetable"
+)
+
+// SourcedLecture pairs a lecture with the subscription it came from, so
+// callers can label it (after cleaning/hashing the raw title) at the point
+// they actually render it, instead of baking the label into Lecture.Title.
+type SourcedLecture struct {
+	timetable.Lecture
+	Sub database.Subscription
+}
+
+func labelTitle(sub database.Subscription, title string) string {
+	if sub.Emoji != "" {
+		return sub.Emoji + " " + title
+	}
+	return "[" + sub.Label + "] " + title
+}
+
+// addUnseen records lecture under key if it hasn't been seen yet, returning
+// false if it was a duplicate. Shared by every merge path below so that
+// overlapping UIDs across subscriptions are only counted once.
+func addUnseen(seen map[string]bool, key string, lecture timetable.Lecture, sub database.Subscription) (SourcedLecture, bool) {
+	if seen[key] {
+		return SourcedLecture{}, false
+	}
+	seen[key] = true
+	return SourcedLecture{Lecture: lecture, Sub: sub}, true
+}
+
+func plainLectures(sourced []SourcedLecture) []timetable.Lecture {
+	lectures := make([]timetable.Lecture, len(sourced))
+	for i, s := range sourced {
+		lectures[i] = s.Lecture
+	}
+	return lectures
+}
+
+func plainLecturesMap(sourced map[string][]SourcedLecture) map[string][]timetable.Lecture {
+	lecturesMap := make(map[string][]timetable.Lecture, len(sourced))
+	for day, lectures := range sourced {
+		lecturesMap[day] = plainLectures(lectures)
+	}
+	return lecturesMap
+}
+
+func (s *Scheduler) fetchLecturesForDay(chatID int64, day time.Time) ([]SourcedLecture, error) {
+	subs, err := s.db.GetSubscriptions(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var merged []SourcedLecture
+
+	for _, sub := range subs {
+		cal, err := timetable.FetchCalendar(sub.WebCalURL)
+		if err != nil {
+			log.Printf("Error fetching calendar %q: %v", sub.Label, err)
+			continue
+		}
+		lectures, err := timetable.GetLectures(cal, day)
+		if err != nil {
+			log.Printf("Error processing calendar %q: %v", sub.Label, err)
+			continue
+		}
+		for _, lecture := range lectures {
+			if sourced, ok := addUnseen(seen, lecture.UID, lecture, sub); ok {
+				merged = append(merged, sourced)
+			}
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Start.Before(merged[j].Start) })
+	return merged, nil
+}
+
+func (s *Scheduler) fetchLecturesInRange(chatID int64, weekStart, weekEnd time.Time) (map[string][]SourcedLecture, error) {
+	subs, err := s.db.GetSubscriptions(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	merged := make(map[string][]SourcedLecture)
+
+	for _, sub := range subs {
+		cal, err := timetable.FetchCalendar(sub.WebCalURL)
+		if err != nil {
+			log.Printf("Error fetching calendar %q: %v", sub.Label, err)
+			continue
+		}
+		lecturesMap, err := timetable.GetLecturesInRange(cal, weekStart, weekEnd)
+		if err != nil {
+			log.Printf("Error processing calendar %q: %v", sub.Label, err)
+			continue
+		}
+		for day, lectures := range lecturesMap {
+			for _, lecture := range lectures {
+				key := day + "|" + lecture.UID
+				if sourced, ok := addUnseen(seen, key, lecture, sub); ok {
+					merged[day] = append(merged[day], sourced)
+				}
+			}
+		}
+	}
+
+	for day := range merged {
+		sort.Slice(merged[day], func(i, j int) bool { return merged[day][i].Start.Before(merged[day][j].Start) })
+	}
+
+	return merged, nil
+}
+
+func (s *Scheduler) HandleAddCalendar(chatID int64, url, label string) error {
+	return s.db.AddSubscription(chatID, url, label)
+}
+
+func (s *Scheduler) HandleRemoveCalendar(chatID int64, label string) error {
+	return s.db.RemoveSubscription(chatID, label)
+}
+
+func (s *Scheduler) ListCalendars(chatID int64) (string, error) {
+	subs, err := s.db.GetSubscriptions(chatID)
+	if err != nil {
+		return "", err
+	}
+	if len(subs) == 0 {
+		return "No calendars added yet. Use /add_calendar <url> <label>.", nil
+	}
+
+	message := "*Your calendars:*\n\n"
+	for _, sub := range subs {
+		prefix := sub.Emoji
+		if prefix == "" {
+			prefix = "•"
+		}
+		message += fmt.Sprintf("%s *%s* — %s\n", prefix, sub.Label, sub.WebCalURL)
+	}
+	return message, nil
+}
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/artem-streltsov/ucl-timetable-bot/database"
+	"github.com/artem-streltsov/ucl-timetable-bot/timetable"
+)
+
+func TestAddUnseen(t *testing.T) {
+	sub := database.Subscription{Label: "Programme"}
+	lecture := timetable.Lecture{UID: "abc", Title: "Algorithms"}
+	seen := make(map[string]bool)
+
+	sourced, ok := addUnseen(seen, lecture.UID, lecture, sub)
+	if !ok {
+		t.Fatal("expected first sighting of a UID to be accepted")
+	}
+	if sourced.UID != "abc" || sourced.Sub.Label != "Programme" {
+		t.Errorf("unexpected sourced lecture: %+v", sourced)
+	}
+
+	friendsSub := database.Subscription{Label: "Friend"}
+	_, ok = addUnseen(seen, lecture.UID, lecture, friendsSub)
+	if ok {
+		t.Error("expected a duplicate UID across subscriptions to be rejected")
+	}
+}
+
+func TestPlainLectures(t *testing.T) {
+	sub := database.Subscription{Label: "Programme"}
+	sourced := []SourcedLecture{
+		{Lecture: timetable.Lecture{UID: "1", Title: "Algorithms"}, Sub: sub},
+		{Lecture: timetable.Lecture{UID: "2", Title: "Compilers"}, Sub: sub},
+	}
+
+	plain := plainLectures(sourced)
+
+	if len(plain) != 2 || plain[0].UID != "1" || plain[1].UID != "2" {
+		t.Errorf("unexpected plain lectures: %+v", plain)
+	}
+}